@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// Finding is an analyzer-produced risk finding, decoupled from how it is
+// ultimately reported (sdk.ResponseBuilder, SARIF, etc.).
+type Finding struct {
+	RiskID      string
+	Severity    pluginv1.Severity
+	Confidence  pluginv1.Confidence
+	Message     string
+	StartLine   int
+	EndLine     int
+	Metadata    map[string]string
+	Fingerprint string
+}
+
+// emit reports f against filePath through resp.
+func (f Finding) emit(resp *sdk.ResponseBuilder, filePath string) {
+	b := resp.Finding(f.RiskID, f.Severity, f.Confidence, f.Message).
+		At(filePath, f.StartLine, f.EndLine).
+		WithFingerprint(f.Fingerprint)
+	for k, v := range f.Metadata {
+		b = b.WithMetadata(k, v)
+	}
+	b.Done()
+}
+
+// Thresholds configures RISK-005 complexity limits. Fields are used as-is;
+// thresholdsFromInput fills any field the request leaves unset (or sets to
+// a non-positive value) from defaultThresholds.
+type Thresholds struct {
+	MaxFuncLines  int
+	MaxCyclomatic int
+	MaxCognitive  int
+	MaxNesting    int
+}
+
+var defaultThresholds = Thresholds{
+	MaxFuncLines:  50,
+	MaxCyclomatic: 10,
+	MaxCognitive:  15,
+	MaxNesting:    4,
+}
+
+// thresholdsFromInput reads max_func_lines/max_cyclomatic/max_cognitive/
+// max_nesting from the tool request, falling back to defaultThresholds for
+// anything unset so users can tune RISK-005 without recompiling.
+func thresholdsFromInput(input map[string]any) Thresholds {
+	t := defaultThresholds
+	if v, ok := intInput(input, "max_func_lines"); ok {
+		t.MaxFuncLines = v
+	}
+	if v, ok := intInput(input, "max_cyclomatic"); ok {
+		t.MaxCyclomatic = v
+	}
+	if v, ok := intInput(input, "max_cognitive"); ok {
+		t.MaxCognitive = v
+	}
+	if v, ok := intInput(input, "max_nesting"); ok {
+		t.MaxNesting = v
+	}
+	return t
+}
+
+// intInput extracts a positive int from a JSON-decoded req.Input value,
+// which arrives as float64.
+func intInput(input map[string]any, key string) (int, bool) {
+	v, ok := input[key].(float64)
+	if !ok || v <= 0 {
+		return 0, false
+	}
+	return int(v), true
+}
+
+// Analyzer produces risk findings for a single source file. Implementations
+// must not touch the filesystem themselves; src is the full file content.
+type Analyzer interface {
+	Analyze(path string, src []byte) []Finding
+}
+
+// analyzerFor returns the most precise Analyzer available for ext, falling
+// back to the regex-based analyzer for languages without an AST backend.
+// catalog and pinned drive RISK-002 detection (see catalog.go).
+func analyzerFor(ext string, t Thresholds, catalog *Catalog, pinned map[string]string) Analyzer {
+	switch ext {
+	case ".go":
+		return &goASTAnalyzer{thresholds: t, catalog: catalog, pinned: pinned}
+	default:
+		return &regexAnalyzer{ext: ext, thresholds: t, catalog: catalog, pinned: pinned}
+	}
+}
+
+// goASTAnalyzer detects RISK-002 (deprecated API usage) and RISK-005 (code
+// complexity) by parsing the file into an AST instead of matching regexes
+// against raw lines. This avoids false positives from unusual formatting,
+// multi-line strings, and comments that happen to contain matching text.
+type goASTAnalyzer struct {
+	thresholds Thresholds
+	catalog    *Catalog
+	pinned     map[string]string
+}
+
+func (a *goASTAnalyzer) Analyze(path string, src []byte) []Finding {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		// Fall back to the regex analyzer for files that don't parse
+		// (e.g. snippets, generated code with syntax errors).
+		return (&regexAnalyzer{ext: ".go", thresholds: a.thresholds, catalog: a.catalog, pinned: a.pinned}).Analyze(path, src)
+	}
+
+	var findings []Finding
+	imports := importAliases(file)
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		findings = append(findings, a.analyzeFunc(fset, fn, path, src)...)
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if f, ok := deprecatedGoCall(fset, sel, imports, a.catalog, a.pinned, src, enclosingFuncSymbol(file, sel.Pos())); ok {
+			findings = append(findings, f)
+		}
+		return true
+	})
+
+	return findings
+}
+
+// analyzeFunc computes line count, cyclomatic complexity, cognitive
+// complexity, and max nesting depth for a single function declaration.
+func (a *goASTAnalyzer) analyzeFunc(fset *token.FileSet, fn *ast.FuncDecl, path string, src []byte) []Finding {
+	startLine := fset.Position(fn.Pos()).Line
+	endLine := fset.Position(fn.End()).Line
+	lineCount := endLine - startLine + 1
+	symbol := funcSymbol(fn)
+	sigLine := sourceLine(src, startLine)
+
+	v := &complexityVisitor{cyclomatic: 1}
+	ast.Walk(v, fn.Body)
+	cyclomatic, cognitive, maxNesting := v.cyclomatic, v.cognitive, v.maxNesting
+
+	var findings []Finding
+	if lineCount > a.thresholds.MaxFuncLines {
+		findings = append(findings, Finding{
+			RiskID:     "RISK-005",
+			Severity:   sdk.SeverityLow,
+			Confidence: sdk.ConfidenceHigh,
+			Message:    fmt.Sprintf("Long function detected (%d lines): increases maintenance risk", lineCount),
+			StartLine:  startLine,
+			EndLine:    endLine,
+			Metadata: map[string]string{
+				"risk_type":  "complexity",
+				"line_count": fmt.Sprintf("%d", lineCount),
+			},
+			Fingerprint: fingerprint("RISK-005", path, sigLine+"|complexity", symbol),
+		})
+	}
+	if cyclomatic > a.thresholds.MaxCyclomatic {
+		findings = append(findings, Finding{
+			RiskID:     "RISK-005",
+			Severity:   sdk.SeverityLow,
+			Confidence: sdk.ConfidenceHigh,
+			Message:    fmt.Sprintf("High cyclomatic complexity (%d): increases risk of untested branches", cyclomatic),
+			StartLine:  startLine,
+			EndLine:    endLine,
+			Metadata: map[string]string{
+				"risk_type":  "cyclomatic_complexity",
+				"complexity": fmt.Sprintf("%d", cyclomatic),
+			},
+			Fingerprint: fingerprint("RISK-005", path, sigLine+"|cyclomatic_complexity", symbol),
+		})
+	}
+	if cognitive > a.thresholds.MaxCognitive {
+		findings = append(findings, Finding{
+			RiskID:     "RISK-005",
+			Severity:   sdk.SeverityLow,
+			Confidence: sdk.ConfidenceHigh,
+			Message:    fmt.Sprintf("High cognitive complexity (%d): harder to reason about than its size suggests", cognitive),
+			StartLine:  startLine,
+			EndLine:    endLine,
+			Metadata: map[string]string{
+				"risk_type":  "cognitive_complexity",
+				"complexity": fmt.Sprintf("%d", cognitive),
+			},
+			Fingerprint: fingerprint("RISK-005", path, sigLine+"|cognitive_complexity", symbol),
+		})
+	}
+	if maxNesting >= a.thresholds.MaxNesting {
+		findings = append(findings, Finding{
+			RiskID:     "RISK-005",
+			Severity:   sdk.SeverityLow,
+			Confidence: sdk.ConfidenceHigh,
+			Message:    fmt.Sprintf("Deeply nested conditional logic (depth %d): increases cognitive complexity", maxNesting),
+			StartLine:  startLine,
+			EndLine:    endLine,
+			Metadata: map[string]string{
+				"risk_type": "nesting_depth",
+				"max_depth": fmt.Sprintf("%d", maxNesting),
+			},
+			Fingerprint: fingerprint("RISK-005", path, sigLine+"|nesting_depth", symbol),
+		})
+	}
+	return findings
+}
+
+// complexityVisitor implements ast.Visitor to compute cyclomatic
+// complexity, cognitive complexity (Sonar's definition: nesting-weighted
+// branch increments), and maximum nesting depth in a single AST walk.
+// Boolean operator sequences raise cyclomatic complexity only; they add
+// nothing to cognitive complexity. It stops descending into nested function literals so a closure's
+// complexity isn't attributed to its enclosing function.
+type complexityVisitor struct {
+	cyclomatic int
+	cognitive  int
+	nesting    int
+	maxNesting int
+}
+
+func (v *complexityVisitor) Visit(n ast.Node) ast.Visitor {
+	switch stmt := n.(type) {
+	case *ast.FuncLit:
+		return nil
+
+	case *ast.IfStmt:
+		v.cyclomatic++
+		v.cognitive += 1 + v.nesting
+		if stmt.Init != nil {
+			ast.Walk(v, stmt.Init)
+		}
+		ast.Walk(v, stmt.Cond)
+		v.descend(stmt.Body)
+		if stmt.Else != nil {
+			if _, chained := stmt.Else.(*ast.IfStmt); chained {
+				// "else if" is a sibling branch, not extra nesting: it costs
+				// a flat +1, so cancel the nesting weight its own visit adds.
+				v.cognitive -= v.nesting
+				ast.Walk(v, stmt.Else)
+			} else {
+				v.cognitive++
+				v.descend(stmt.Else)
+			}
+		}
+		return nil
+
+	case *ast.ForStmt:
+		v.cyclomatic++
+		v.cognitive += 1 + v.nesting
+		v.descend(stmt.Body)
+		return nil
+
+	case *ast.RangeStmt:
+		v.cyclomatic++
+		v.cognitive += 1 + v.nesting
+		v.descend(stmt.Body)
+		return nil
+
+	case *ast.SwitchStmt:
+		v.cyclomatic += len(stmt.Body.List)
+		v.cognitive += 1 + v.nesting
+		v.descend(stmt.Body)
+		return nil
+
+	case *ast.TypeSwitchStmt:
+		v.cyclomatic += len(stmt.Body.List)
+		v.cognitive += 1 + v.nesting
+		v.descend(stmt.Body)
+		return nil
+
+	case *ast.BinaryExpr:
+		if stmt.Op == token.LAND || stmt.Op == token.LOR {
+			v.cyclomatic++
+		}
+	}
+	return v
+}
+
+// descend walks n one nesting level deeper, tracking the deepest level
+// reached so far.
+func (v *complexityVisitor) descend(n ast.Node) {
+	v.nesting++
+	if v.nesting > v.maxNesting {
+		v.maxNesting = v.nesting
+	}
+	ast.Walk(v, n)
+	v.nesting--
+}
+
+// importAliases maps the local identifier a file uses for each imported
+// package back to its import path, so `ioutil.ReadAll` resolves to
+// "io/ioutil" even if the import was aliased.
+func importAliases(file *ast.File) map[string]string {
+	aliases := make(map[string]string)
+	for _, imp := range file.Imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		aliases[name] = path
+	}
+	return aliases
+}
+
+// deprecatedGoCall reports whether sel references a catalog-listed
+// deprecated qualified identifier, resolved through the file's import
+// aliases rather than by matching the raw selector text. enclosingSymbol
+// (see enclosingFuncSymbol) is carried through into the finding's
+// fingerprint so the same deprecated call in two different functions of the
+// same file doesn't collide.
+func deprecatedGoCall(fset *token.FileSet, sel *ast.SelectorExpr, imports map[string]string, catalog *Catalog, pinned map[string]string, src []byte, enclosingSymbol string) (Finding, bool) {
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return Finding{}, false
+	}
+	path, ok := imports[ident.Name]
+	if !ok {
+		return Finding{}, false
+	}
+	entry, ok := catalog.deprecatedGoSymbol(path, sel.Sel.Name, pinned)
+	if !ok {
+		return Finding{}, false
+	}
+	line := fset.Position(sel.Pos()).Line
+	filePath := fset.Position(sel.Pos()).Filename
+	return Finding{
+		RiskID:     "RISK-002",
+		Severity:   entry.findingSeverity(),
+		Confidence: sdk.ConfidenceHigh,
+		Message:    fmt.Sprintf("Deprecated API usage detected (Go deprecated API): %s.%s — replacement: %s", ident.Name, sel.Sel.Name, entry.Replacement),
+		StartLine:  line,
+		EndLine:    line,
+		Metadata: map[string]string{
+			"risk_type": "deprecated_api",
+			"language":  ".go",
+			"package":   path,
+			"symbol":    sel.Sel.Name,
+		},
+		Fingerprint: fingerprint("RISK-002", filePath, sourceLine(src, line), enclosingSymbol),
+	}, true
+}
+
+// enclosingFuncSymbol returns funcSymbol for the FuncDecl in file containing
+// pos, or "" if pos falls outside every function (e.g. a package-level var
+// initializer).
+func enclosingFuncSymbol(file *ast.File, pos token.Pos) string {
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		if pos >= fn.Pos() && pos <= fn.End() {
+			return funcSymbol(fn)
+		}
+	}
+	return ""
+}
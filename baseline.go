@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// defaultBaselineFile is the baseline path used when req.Input["baseline"]
+// is unset.
+const defaultBaselineFile = ".noxrisk-baseline.json"
+
+// fingerprint derives a stable identity for a finding from its rule,
+// location, and a normalized line-content hash, so the same issue keeps the
+// same fingerprint across formatting-only diffs. symbol is the enclosing
+// function/method when known, and further disambiguates findings that would
+// otherwise collide (e.g. the same deprecated call repeated verbatim in two
+// functions in the same file).
+func fingerprint(riskID, filePath, lineText, symbol string) string {
+	h := sha256.Sum256([]byte(riskID + "|" + filePath + "|" + normalizeLine(lineText) + "|" + symbol))
+	return hex.EncodeToString(h[:])[:16]
+}
+
+// normalizeLine collapses leading/trailing and repeated whitespace so
+// reformatting (tabs vs spaces, trailing whitespace) doesn't change a
+// finding's fingerprint.
+func normalizeLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// sourceLine returns the 1-indexed line of src at lineNum, or "" if out of
+// range.
+func sourceLine(src []byte, lineNum int) string {
+	lines := strings.Split(string(src), "\n")
+	if lineNum < 1 || lineNum > len(lines) {
+		return ""
+	}
+	return lines[lineNum-1]
+}
+
+// --- Baseline file ---
+
+// baselineEntry is one previously-seen finding recorded in the baseline
+// file, kept human-readable so reviewers can see what a fingerprint means
+// without cross-referencing a live scan.
+type baselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	RuleID      string `json:"rule_id"`
+	File        string `json:"file"`
+	Message     string `json:"message"`
+}
+
+// baselineFile is the on-disk format of .noxrisk-baseline.json.
+type baselineFile struct {
+	Version  int             `json:"version"`
+	Findings []baselineEntry `json:"findings"`
+}
+
+const baselineVersion = 1
+
+// baselinePath resolves the baseline file location: req.Input["baseline"]
+// if set, else defaultBaselineFile under workspaceRoot.
+func baselinePath(input map[string]any, workspaceRoot string) string {
+	if p, ok := input["baseline"].(string); ok && p != "" {
+		return p
+	}
+	return filepath.Join(workspaceRoot, defaultBaselineFile)
+}
+
+// loadBaseline reads path into a fingerprint -> entry map. A missing file is
+// treated as an empty baseline (every finding is "new") rather than an
+// error, since that's the expected state before a project's first
+// `update_baseline` run.
+func loadBaseline(path string) (map[string]baselineEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]baselineEntry{}, nil
+		}
+		return nil, err
+	}
+	var bf baselineFile
+	if err := json.Unmarshal(data, &bf); err != nil {
+		return nil, err
+	}
+	entries := make(map[string]baselineEntry, len(bf.Findings))
+	for _, e := range bf.Findings {
+		entries[e.Fingerprint] = e
+	}
+	return entries, nil
+}
+
+// writeBaseline records every finding in findings to path, deduplicated by
+// fingerprint.
+func writeBaseline(path string, findings []*pluginv1.Finding) error {
+	seen := map[string]bool{}
+	bf := baselineFile{Version: baselineVersion}
+	for _, f := range findings {
+		if seen[f.Fingerprint] {
+			continue
+		}
+		seen[f.Fingerprint] = true
+		file := ""
+		if f.Location != nil {
+			file = f.Location.FilePath
+		}
+		bf.Findings = append(bf.Findings, baselineEntry{
+			Fingerprint: f.Fingerprint,
+			RuleID:      f.RuleId,
+			File:        file,
+			Message:     f.Message,
+		})
+	}
+	data, err := json.MarshalIndent(bf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// applyBaseline filters resp's findings down to those whose fingerprint
+// isn't already recorded in the baseline at path and reports a summary of
+// baseline-suppressed and now-fixed findings as diagnostics. When update is
+// true it instead (re)writes the baseline from the full finding set so the
+// newly-adopted state becomes the new baseline, and leaves resp's findings
+// unfiltered.
+func applyBaseline(resp *sdk.ResponseBuilder, path string, update bool) {
+	baseline, err := loadBaseline(path)
+	if err != nil {
+		resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+			fmt.Sprintf("failed to read baseline %s: %v", path, err), "risk-register")
+		baseline = map[string]baselineEntry{}
+	}
+
+	all := resp.Build().Findings
+
+	if update {
+		if err := writeBaseline(path, all); err != nil {
+			resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+				fmt.Sprintf("failed to write baseline %s: %v", path, err), "risk-register")
+		} else {
+			resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+				fmt.Sprintf("baseline written to %s (%d findings)", path, len(all)), "risk-register")
+		}
+		return
+	}
+
+	if len(baseline) == 0 {
+		return
+	}
+
+	seenNow := map[string]bool{}
+	newFindings := make([]*pluginv1.Finding, 0, len(all))
+	suppressed := 0
+	for _, f := range all {
+		seenNow[f.Fingerprint] = true
+		if _, ok := baseline[f.Fingerprint]; ok {
+			suppressed++
+			continue
+		}
+		newFindings = append(newFindings, f)
+	}
+	resp.Build().Findings = newFindings
+
+	var fixed []baselineEntry
+	for fp, entry := range baseline {
+		if !seenNow[fp] {
+			fixed = append(fixed, entry)
+		}
+	}
+
+	if suppressed > 0 {
+		resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("%d pre-existing finding(s) suppressed by baseline %s", suppressed, path), "risk-register")
+	}
+	if len(fixed) > 0 {
+		resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("%d baselined finding(s) no longer reproduce (fixed): %s", len(fixed), fixedSummary(fixed)), "risk-register")
+	}
+}
+
+// fixedSummary renders up to 5 fixed baseline entries as "RULE in file",
+// truncating the rest into a "+N more" suffix so the diagnostic stays short.
+func fixedSummary(fixed []baselineEntry) string {
+	const max = 5
+	parts := make([]string, 0, max)
+	for i, e := range fixed {
+		if i >= max {
+			parts = append(parts, fmt.Sprintf("+%d more", len(fixed)-max))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s in %s", e.RuleID, e.File))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// --- Inline suppressions ---
+
+// reRiskIgnore matches an inline suppression comment, e.g.
+// "// nox:risk-ignore RISK-001 reason=tracked in JIRA-123".
+var reRiskIgnore = regexp.MustCompile(`nox:risk-ignore\s+(RISK-\d+)`)
+
+// parseSuppressions scans src for inline "nox:risk-ignore RISK-XXX" markers
+// and returns a line -> rule ID set of what's suppressed on each line.
+func parseSuppressions(src []byte) map[int]map[string]bool {
+	suppressed := map[int]map[string]bool{}
+	for i, line := range strings.Split(string(src), "\n") {
+		m := reRiskIgnore.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		lineNum := i + 1
+		if suppressed[lineNum] == nil {
+			suppressed[lineNum] = map[string]bool{}
+		}
+		suppressed[lineNum][m[1]] = true
+	}
+	return suppressed
+}
+
+// isSuppressed reports whether ruleID is suppressed on any line in
+// [startLine, endLine] of filePath, per the suppression markers
+// scanFileForRisks collected into rc.suppressed.
+func isSuppressed(rc *riskContext, filePath string, startLine, endLine int, ruleID string) bool {
+	byLine := rc.suppressed[filePath]
+	if byLine == nil {
+		return false
+	}
+	for line := startLine; line <= endLine; line++ {
+		if byLine[line][ruleID] {
+			return true
+		}
+	}
+	return false
+}
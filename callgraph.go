@@ -0,0 +1,202 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// callSite is a single unguarded DB-open or external-call site, attributed
+// to the file/line it occurs at and (for Go) the function it occurs in.
+type callSite struct {
+	File          string
+	Line          int
+	Target        string
+	EnclosingFunc string
+}
+
+// funcInfo records what a single Go function calls and which guard
+// constructs (pooling, fallback, retry/circuit-breaker) appear directly in
+// its body (HasRetry is also set when its file imports a catalog-listed
+// retry library), keyed by an approximate symbol name ("Type.Method" for methods,
+// "Name" otherwise). Symbols are not package-qualified, so this is a
+// best-effort, single-workspace index rather than a precise call graph.
+type funcInfo struct {
+	HasPooling  bool
+	HasFallback bool
+	HasRetry    bool
+	calls       []string
+}
+
+// goCallIndex is a lightweight, whole-workspace call graph for Go built
+// from the AST rather than go/packages, so it works without a resolvable
+// module graph. It answers "does this function or one of its callers within
+// N hops guard this call" without full type-checking. Callers are linked by
+// the callee's call-expression text, so only unqualified calls to plain
+// functions (e.g. "fetch()") connect a function to its callers; callers of
+// methods and package-qualified calls are not found.
+type goCallIndex struct {
+	funcs        map[string]*funcInfo
+	reverseCalls map[string][]string // callee -> callers
+}
+
+// buildGoCallIndex walks every .go file under workspaceRoot, indexing
+// functions/methods and the DB-open and external-call sites found in them.
+// catalog augments HasRetry detection: a function is also considered
+// retry-guarded if the file imports a catalog-listed retry/circuit-breaker
+// library, even when the function body doesn't mention "retry" by name.
+func buildGoCallIndex(workspaceRoot string, catalog *Catalog) (*goCallIndex, []callSite, []callSite) {
+	idx := &goCallIndex{funcs: map[string]*funcInfo{}}
+	var dbSites, externalSites []callSite
+
+	_ = filepath.WalkDir(workspaceRoot, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, src, 0)
+		if err != nil {
+			return nil
+		}
+
+		var fileImports []string
+		for _, imp := range file.Imports {
+			fileImports = append(fileImports, strings.Trim(imp.Path.Value, `"`))
+		}
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			name := funcSymbol(fn)
+			info := &funcInfo{}
+
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				target := types.ExprString(call.Fun)
+				callText := target + "("
+
+				switch {
+				case reSingleDBConn.MatchString(callText):
+					dbSites = append(dbSites, callSite{
+						File: path, Line: fset.Position(call.Pos()).Line,
+						Target: target, EnclosingFunc: name,
+					})
+				case reExternalCall.MatchString(callText):
+					externalSites = append(externalSites, callSite{
+						File: path, Line: fset.Position(call.Pos()).Line,
+						Target: target, EnclosingFunc: name,
+					})
+				}
+				info.calls = append(info.calls, target)
+				return true
+			})
+
+			body := src[fset.Position(fn.Pos()).Offset:fset.Position(fn.End()).Offset]
+			info.HasPooling = reNoPooling.Match(body)
+			info.HasFallback = reNoFallback.Match(body)
+			info.HasRetry = reRetryMechanism.Match(body) || catalog.hasRetryLibrary("go", fileImports)
+
+			idx.funcs[name] = info
+		}
+		return nil
+	})
+
+	idx.reverseCalls = make(map[string][]string)
+	for caller, info := range idx.funcs {
+		for _, callee := range info.calls {
+			idx.reverseCalls[callee] = append(idx.reverseCalls[callee], caller)
+		}
+	}
+
+	return idx, dbSites, externalSites
+}
+
+// funcSymbol derives an approximate, unqualified symbol name for fn:
+// "Type.Method" for methods, "Name" for plain functions.
+func funcSymbol(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recvType := types.ExprString(fn.Recv.List[0].Type)
+	for len(recvType) > 0 && recvType[0] == '*' {
+		recvType = recvType[1:]
+	}
+	return recvType + "." + fn.Name.Name
+}
+
+// guardKind selects which guard flag on funcInfo protects a call kind.
+type guardKind int
+
+const (
+	guardDB guardKind = iota
+	guardExternal
+)
+
+// hasGuardWithinHops reports whether funcName, or a caller of funcName
+// reachable within maxHops call-graph edges, contains a guard construct
+// appropriate for kind (pooling/fallback for DB, retry/circuit-breaker for
+// external calls).
+func (idx *goCallIndex) hasGuardWithinHops(funcName string, maxHops int, kind guardKind) bool {
+	if idx == nil {
+		return false
+	}
+	type queued struct {
+		name string
+		hop  int
+	}
+	visited := map[string]bool{}
+	queue := []queued{{funcName, 0}}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if visited[cur.name] {
+			continue
+		}
+		visited[cur.name] = true
+
+		if info := idx.funcs[cur.name]; info != nil {
+			guarded := false
+			switch kind {
+			case guardDB:
+				guarded = info.HasPooling || info.HasFallback
+			case guardExternal:
+				guarded = info.HasRetry
+			}
+			if guarded {
+				return true
+			}
+		}
+
+		if cur.hop >= maxHops {
+			continue
+		}
+		for _, caller := range idx.reverseCalls[cur.name] {
+			queue = append(queue, queued{caller, cur.hop + 1})
+		}
+	}
+	return false
+}
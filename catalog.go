@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+//go:embed catalog_default.json
+var embeddedCatalogJSON []byte
+
+// CatalogEntry is a single deprecated-API/pattern record. A Go-style entry
+// matches a qualified Package (+ optional Symbol) resolved via the AST;
+// languages without an AST backend instead set Pattern, a regex matched
+// against raw source lines.
+type CatalogEntry struct {
+	Language        string `json:"language"`
+	Package         string `json:"package,omitempty"`
+	Symbol          string `json:"symbol,omitempty"`
+	Pattern         string `json:"pattern,omitempty"`
+	DeprecatedSince string `json:"deprecated_since,omitempty"`
+	Replacement     string `json:"replacement,omitempty"`
+	Severity        string `json:"severity,omitempty"`
+
+	compiled *regexp.Regexp
+}
+
+// RetryLibrary identifies a package recognized as providing retry or
+// circuit-breaker behavior for RISK-004 purposes.
+type RetryLibrary struct {
+	Language string `json:"language"`
+	Package  string `json:"package"`
+}
+
+// Catalog is the data-driven replacement for the plugin's original
+// hand-written deprecation/retry regexes. Users can override it entirely
+// via req.Input["catalog_path"]; unset falls back to the embedded default.
+type Catalog struct {
+	Deprecations   []CatalogEntry `json:"deprecations"`
+	RetryLibraries []RetryLibrary `json:"retry_libraries"`
+}
+
+// loadCatalog reads the catalog named by input["catalog_path"], or the
+// embedded default if unset.
+func loadCatalog(input map[string]any) (*Catalog, error) {
+	data := embeddedCatalogJSON
+	if path, ok := input["catalog_path"].(string); ok && path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	}
+
+	var cat Catalog
+	if err := json.Unmarshal(data, &cat); err != nil {
+		return nil, err
+	}
+	for i := range cat.Deprecations {
+		if cat.Deprecations[i].Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(cat.Deprecations[i].Pattern)
+		if err != nil {
+			continue // skip malformed patterns rather than fail the whole scan
+		}
+		cat.Deprecations[i].compiled = re
+	}
+	return &cat, nil
+}
+
+// findingSeverity maps the entry's severity string to an sdk constant,
+// defaulting to SeverityMedium (the plugin's original RISK-002 severity)
+// when unset or unrecognized.
+func (e CatalogEntry) findingSeverity() pluginv1.Severity {
+	switch strings.ToLower(e.Severity) {
+	case "critical":
+		return sdk.SeverityCritical
+	case "high":
+		return sdk.SeverityHigh
+	case "low":
+		return sdk.SeverityLow
+	default:
+		return sdk.SeverityMedium
+	}
+}
+
+// deprecatedGoSymbol looks up a Go package.symbol reference in the catalog,
+// gated by pinned[Package] against DeprecatedSince when both are set.
+func (c *Catalog) deprecatedGoSymbol(pkg, symbol string, pinned map[string]string) (CatalogEntry, bool) {
+	if c == nil {
+		return CatalogEntry{}, false
+	}
+	for _, e := range c.Deprecations {
+		if e.Language != "go" || e.Package != pkg {
+			continue
+		}
+		if e.Symbol != "" && e.Symbol != symbol {
+			continue
+		}
+		if !versionApplies(e, pinned) {
+			continue
+		}
+		return e, true
+	}
+	return CatalogEntry{}, false
+}
+
+// matchPattern scans line against every pattern-based entry for language,
+// gated by pinned[Package] against DeprecatedSince when both are set.
+func (c *Catalog) matchPattern(language, line string, pinned map[string]string) (CatalogEntry, bool) {
+	if c == nil {
+		return CatalogEntry{}, false
+	}
+	for _, e := range c.Deprecations {
+		if e.Language != language || e.compiled == nil {
+			continue
+		}
+		if !versionApplies(e, pinned) {
+			continue
+		}
+		if e.compiled.MatchString(line) {
+			return e, true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+// versionApplies reports whether entry should be flagged given pinned
+// versions: entries without a DeprecatedSince, or whose package has no
+// pinned version, are always flagged (conservative default). Otherwise the
+// entry only applies once the pinned version reaches DeprecatedSince.
+func versionApplies(e CatalogEntry, pinned map[string]string) bool {
+	if e.DeprecatedSince == "" || e.Package == "" {
+		return true
+	}
+	v, ok := pinned[e.Package]
+	if !ok {
+		return true
+	}
+	return compareVersions(v, e.DeprecatedSince) >= 0
+}
+
+// hasRetryLibrary reports whether any of imports names a package the
+// catalog recognizes as a retry/circuit-breaker library for language.
+func (c *Catalog) hasRetryLibrary(language string, imports []string) bool {
+	if c == nil {
+		return false
+	}
+	for _, lib := range c.RetryLibraries {
+		if lib.Language != language {
+			continue
+		}
+		for _, imp := range imports {
+			if imp == lib.Package || strings.Contains(imp, lib.Package) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasRetrySubstring reports whether src textually references any cataloged
+// retry library for language — the non-Go, non-import-list fallback.
+func (c *Catalog) hasRetrySubstring(language string, src []byte) bool {
+	if c == nil {
+		return false
+	}
+	text := string(src)
+	for _, lib := range c.RetryLibraries {
+		if lib.Language == language && strings.Contains(text, lib.Package) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions does a best-effort numeric comparison of dotted version
+// strings (an optional leading "v" and any non-numeric pre-release suffix
+// are ignored), returning -1, 0, or 1. Unparseable segments compare as 0.
+func compareVersions(a, b string) int {
+	pa, pb := versionSegments(a), versionSegments(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na = pa[i]
+		}
+		if i < len(pb) {
+			nb = pb[i]
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionSegments(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	segs := make([]int, len(parts))
+	for i, p := range parts {
+		n, _ := strconv.Atoi(p)
+		segs[i] = n
+	}
+	return segs
+}
+
+// loadPinnedVersions best-effort parses go.mod, package.json, and
+// requirements.txt at workspaceRoot into a package-name -> pinned-version
+// map, so catalog lookups can skip deprecations not yet reached. Any file
+// that's missing or fails to parse is silently skipped.
+func loadPinnedVersions(workspaceRoot string) map[string]string {
+	pinned := map[string]string{}
+	for k, v := range parseGoMod(filepath.Join(workspaceRoot, "go.mod")) {
+		pinned[k] = v
+	}
+	for k, v := range parsePackageJSON(filepath.Join(workspaceRoot, "package.json")) {
+		pinned[k] = v
+	}
+	for k, v := range parseRequirementsTxt(filepath.Join(workspaceRoot, "requirements.txt")) {
+		pinned[k] = v
+	}
+	return pinned
+}
+
+var reGoModRequire = regexp.MustCompile(`^\s*([^\s]+)\s+(v[0-9][^\s]*)`)
+
+// parseGoMod extracts module -> version from require lines, handling both
+// single-line ("require foo v1.2.3") and block ("require (...)") forms.
+func parseGoMod(path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	versions := map[string]string{}
+	inBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "require ("):
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+			// fallthrough to matching below
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimPrefix(line, "require ")
+		default:
+			continue
+		}
+		if m := reGoModRequire.FindStringSubmatch(line); m != nil {
+			versions[m[1]] = m[2]
+		}
+	}
+	return versions
+}
+
+// parsePackageJSON extracts name -> version from dependencies and
+// devDependencies, stripping semver range prefixes like ^ and ~.
+func parsePackageJSON(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+	versions := map[string]string{}
+	for name, v := range pkg.Dependencies {
+		versions[name] = strings.TrimLeft(v, "^~=")
+	}
+	for name, v := range pkg.DevDependencies {
+		versions[name] = strings.TrimLeft(v, "^~=")
+	}
+	return versions
+}
+
+var reRequirementPin = regexp.MustCompile(`^([A-Za-z0-9_.\-]+)\s*(?:==|>=|~=)\s*([0-9][0-9A-Za-z.\-]*)`)
+
+// parseRequirementsTxt extracts name -> version from pinned requirement
+// lines (name==version, name>=version, name~=version). Unpinned lines are
+// skipped since there's no version to compare against.
+func parseRequirementsTxt(path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	versions := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := reRequirementPin.FindStringSubmatch(line); m != nil {
+			versions[m[1]] = m[2]
+		}
+	}
+	return versions
+}
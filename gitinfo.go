@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// defaultChurnDays is the lookback window for per-file change frequency,
+// used when req.Input["git_churn_days"] is unset.
+const defaultChurnDays = 30
+
+// defaultHotspotChurn is the minimum number of commits touching a file
+// within the churn window for isHot to treat it as "hot", when req.Input["git_hotspot_churn"] is unset.
+const defaultHotspotChurn = 5
+
+// gitEnabled reports whether git-aware scanning should run: explicitly via
+// req.Input["use_git"], or implicitly whenever workspaceRoot looks like a
+// git checkout.
+func gitEnabled(input map[string]any, workspaceRoot string) bool {
+	if v, ok := input["use_git"].(bool); ok {
+		return v
+	}
+	_, err := os.Stat(filepath.Join(workspaceRoot, ".git"))
+	return err == nil
+}
+
+// gitInfo wraps the workspace's repository and caches the per-file churn
+// and blame data that hotspot scoring needs, so a workspace with many
+// findings in the same file only walks its history once.
+type gitInfo struct {
+	repo       *git.Repository
+	root       string
+	head       *object.Commit
+	since      time.Time
+	hotspotMin int
+
+	churnCache map[string]int
+	blameCache map[string]*git.BlameResult
+}
+
+// openGitInfo opens the repository at workspaceRoot and resolves HEAD. The
+// churn window and hotspot threshold are read from req.Input, falling back
+// to defaultChurnDays/defaultHotspotChurn.
+func openGitInfo(workspaceRoot string, input map[string]any) (*gitInfo, error) {
+	repo, err := git.PlainOpen(workspaceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("opening git repository: %w", err)
+	}
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("resolving HEAD: %w", err)
+	}
+	head, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("loading HEAD commit: %w", err)
+	}
+
+	churnDays := defaultChurnDays
+	if v, ok := intInput(input, "git_churn_days"); ok {
+		churnDays = v
+	}
+	hotspotMin := defaultHotspotChurn
+	if v, ok := intInput(input, "git_hotspot_churn"); ok {
+		hotspotMin = v
+	}
+
+	return &gitInfo{
+		repo:       repo,
+		root:       workspaceRoot,
+		head:       head,
+		since:      head.Committer.When.AddDate(0, 0, -churnDays),
+		hotspotMin: hotspotMin,
+		churnCache: map[string]int{},
+		blameCache: map[string]*git.BlameResult{},
+	}, nil
+}
+
+// relPath converts a file path under g.root, as stored on a Finding's
+// Location (workspaceRoot joined with the file's path), into the
+// repo-relative, slash-separated form go-git expects. Paths outside g.root
+// yield ok=false.
+func (g *gitInfo) relPath(filePath string) (string, bool) {
+	rel, err := filepath.Rel(g.root, filePath)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == ".." || len(rel) >= 2 && rel[:2] == ".." {
+		return "", false
+	}
+	return rel, true
+}
+
+// churnFor returns the number of commits that touched relPath since
+// g.since, caching the result per file.
+func (g *gitInfo) churnFor(relPath string) int {
+	if n, ok := g.churnCache[relPath]; ok {
+		return n
+	}
+
+	since := g.since
+	iter, err := g.repo.Log(&git.LogOptions{From: g.head.Hash, FileName: &relPath, Since: &since})
+	if err != nil {
+		g.churnCache[relPath] = 0
+		return 0
+	}
+	defer iter.Close()
+
+	n := 0
+	_ = iter.ForEach(func(*object.Commit) error {
+		n++
+		return nil
+	})
+	g.churnCache[relPath] = n
+	return n
+}
+
+// isHot reports whether relPath's churn meets g.hotspotMin — the classic
+// "hotspot" heuristic of churn x complexity, applied here to flag files
+// whose change frequency alone warrants closer scrutiny.
+func (g *gitInfo) isHot(relPath string) bool {
+	return g.churnFor(relPath) >= g.hotspotMin
+}
+
+// blameFor returns the cached blame result for relPath at HEAD.
+func (g *gitInfo) blameFor(relPath string) (*git.BlameResult, bool) {
+	if b, ok := g.blameCache[relPath]; ok {
+		return b, b != nil
+	}
+	b, err := git.Blame(g.head, relPath)
+	if err != nil {
+		g.blameCache[relPath] = nil
+		return nil, false
+	}
+	g.blameCache[relPath] = b
+	return b, true
+}
+
+// lineInfo returns the last author, short commit hash, and age in days of
+// relPath's line at HEAD, or ok=false if blame information isn't available
+// for that line (e.g. it falls outside the file as currently committed).
+func (g *gitInfo) lineInfo(relPath string, line int) (author, commit string, ageDays int, ok bool) {
+	b, ok := g.blameFor(relPath)
+	if !ok || line < 1 || line > len(b.Lines) {
+		return "", "", 0, false
+	}
+	l := b.Lines[line-1]
+	hash := l.Hash.String()
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	return l.AuthorName, hash, int(time.Since(l.Date).Hours() / 24), true
+}
+
+// escalateSeverity bumps severity one step toward SeverityCritical, used to
+// raise RISK-005 findings in hot files where churn compounds complexity.
+func escalateSeverity(s pluginv1.Severity) pluginv1.Severity {
+	switch s {
+	case sdk.SeverityInfo:
+		return sdk.SeverityLow
+	case sdk.SeverityLow:
+		return sdk.SeverityMedium
+	case sdk.SeverityMedium:
+		return sdk.SeverityHigh
+	case sdk.SeverityHigh:
+		return sdk.SeverityCritical
+	default:
+		return s
+	}
+}
+
+// enrichWithGit, when git-aware scanning is enabled (see gitEnabled),
+// attaches last_author/last_commit/age_days metadata to every finding,
+// raises the severity of RISK-005 findings in hot files (churn x
+// complexity), and emits one RISK-006 finding per hot file that also
+// carries RISK-001 security-related debt.
+func enrichWithGit(resp *sdk.ResponseBuilder, workspaceRoot string, input map[string]any) {
+	if !gitEnabled(input, workspaceRoot) {
+		return
+	}
+	gi, err := openGitInfo(workspaceRoot, input)
+	if err != nil {
+		resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+			fmt.Sprintf("git-aware scanning disabled: %v", err), "risk-register")
+		return
+	}
+
+	hotSecurityDebt := map[string]int{}
+	for _, f := range resp.Build().Findings {
+		if f.Location == nil {
+			continue
+		}
+		rel, ok := gi.relPath(f.Location.FilePath)
+		if !ok {
+			continue
+		}
+		hot := gi.isHot(rel)
+
+		if author, commit, ageDays, ok := gi.lineInfo(rel, int(f.Location.StartLine)); ok {
+			if f.Metadata == nil {
+				f.Metadata = map[string]string{}
+			}
+			f.Metadata["last_author"] = author
+			f.Metadata["last_commit"] = commit
+			f.Metadata["age_days"] = fmt.Sprintf("%d", ageDays)
+		}
+
+		if !hot {
+			continue
+		}
+		if f.RuleId == "RISK-005" {
+			f.Severity = escalateSeverity(f.Severity)
+			f.Metadata["hotspot"] = "true"
+			f.Metadata["churn_commits"] = fmt.Sprintf("%d", gi.churnFor(rel))
+		}
+		if f.RuleId == "RISK-001" {
+			hotSecurityDebt[f.Location.FilePath]++
+		}
+	}
+
+	for filePath, count := range hotSecurityDebt {
+		rel, _ := gi.relPath(filePath)
+		resp.Finding(
+			"RISK-006",
+			sdk.SeverityHigh,
+			sdk.ConfidenceMedium,
+			fmt.Sprintf("High-churn file with %d security-related debt marker(s): frequent changes compound unresolved security risk", count),
+		).
+			At(filePath, 1, 1).
+			WithMetadata("risk_type", "hotspot_security_debt").
+			WithMetadata("security_debt_count", fmt.Sprintf("%d", count)).
+			WithMetadata("churn_commits", fmt.Sprintf("%d", gi.churnFor(rel))).
+			WithFingerprint(fingerprint("RISK-006", filePath, "hotspot_security_debt", "")).
+			Done()
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -22,10 +23,7 @@ var (
 	// RISK-001: Security-related technical debt markers.
 	reSecurityTODO = regexp.MustCompile(`(?i)(TODO|FIXME|HACK|XXX)\s*:?\s*.*(security|auth|crypt|password|secret|token|vulnerab|inject|xss|csrf|sanitiz|escap|privilege|permiss)`)
 
-	// RISK-002: Deprecated API/pattern usage.
-	reDeprecatedGo     = regexp.MustCompile(`(?i)(ioutil\.|x509\.ParseCRL|http\.ListenAndServeTLS\(|md5\.New\(\)|sha1\.New\(\)|des\.NewCipher)`)
-	reDeprecatedPython = regexp.MustCompile(`(?i)(import\s+md5|import\s+sha\b|from\s+sha\s+import|\.has_key\(|print\s+[^(]|raw_input|execfile|reload\()`)
-	reDeprecatedJS     = regexp.MustCompile(`(?i)(document\.write\(|escape\(|unescape\(|__proto__|Object\.observe|\.substr\()`)
+	// RISK-002: Deprecated API/pattern usage is catalog-driven; see catalog.go.
 
 	// RISK-003: Single point of failure patterns.
 	reSingleDBConn = regexp.MustCompile(`(?i)(sql\.Open\(|connect\(|createConnection\(|MongoClient\()`)
@@ -64,19 +62,44 @@ var skippedDirs = map[string]bool{
 	"build":        true,
 }
 
-// riskContext tracks workspace-level risk indicators.
-type riskContext struct {
-	hasDBConnection  bool
-	hasPooling       bool
-	hasFallback      bool
-	hasExternalCalls bool
-	hasRetryMech     bool
+// scanConfig holds per-invocation options derived from req.Input.
+type scanConfig struct {
+	thresholds     Thresholds
+	catalog        *Catalog
+	pinnedVersions map[string]string
+}
+
+// configFromInput builds a scanConfig from the tool request's input map,
+// loading the deprecation/retry-library catalog (req.Input["catalog_path"]
+// or the embedded default; see catalog.go) and the dependency versions
+// pinned in workspaceRoot's go.mod/package.json/requirements.txt.
+func configFromInput(input map[string]any, workspaceRoot string) *scanConfig {
+	catalog, err := loadCatalog(input)
+	if err != nil {
+		// Fall back to an empty catalog rather than failing the scan outright;
+		// RISK-002 simply finds nothing until the override is fixed.
+		catalog = &Catalog{}
+	}
+	return &scanConfig{
+		thresholds:     thresholdsFromInput(input),
+		catalog:        catalog,
+		pinnedVersions: loadPinnedVersions(workspaceRoot),
+	}
+}
 
-	dbFile string
-	dbLine int
+// maxCallerHops bounds how far up the call graph hasGuardWithinHops looks
+// for a pooling/retry construct before giving up on a call site.
+const maxCallerHops = 2
 
-	externalCallFile string
-	externalCallLine int
+// riskContext tracks the DB-open and external-call sites found while
+// walking the workspace, plus the Go call graph used to decide whether
+// each Go call site is guarded by a caller within maxCallerHops, and the
+// inline suppressions collected from each file (file -> line -> rule ID).
+type riskContext struct {
+	goIndex       *goCallIndex
+	dbSites       []callSite
+	externalSites []callSite
+	suppressed    map[string]map[int]map[string]bool
 }
 
 func buildServer() *sdk.PluginServer {
@@ -103,7 +126,15 @@ func handleScan(ctx context.Context, req sdk.ToolRequest) (*pluginv1.InvokeToolR
 		return resp.Build(), nil
 	}
 
-	rc := &riskContext{}
+	cfg := configFromInput(req.Input, workspaceRoot)
+
+	goIndex, dbSites, externalSites := buildGoCallIndex(workspaceRoot, cfg.catalog)
+	rc := &riskContext{
+		goIndex:       goIndex,
+		dbSites:       dbSites,
+		externalSites: externalSites,
+		suppressed:    map[string]map[int]map[string]bool{},
+	}
 
 	err := filepath.WalkDir(workspaceRoot, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -124,140 +155,159 @@ func handleScan(ctx context.Context, req sdk.ToolRequest) (*pluginv1.InvokeToolR
 			return nil
 		}
 
-		scanFileForRisks(resp, rc, path, ext)
+		scanFileForRisks(resp, rc, path, ext, cfg)
 		return nil
 	})
 	if err != nil && err != context.Canceled {
 		return nil, fmt.Errorf("walking workspace: %w", err)
 	}
 
-	// Emit workspace-level findings.
-	emitWorkspaceRisks(resp, rc)
+	// Emit one RISK-003/RISK-004 finding per unguarded Go call site.
+	emitCallSiteRisks(resp, rc)
+
+	enrichWithGit(resp, workspaceRoot, req.Input)
+
+	updateBaseline, _ := req.Input["update_baseline"].(bool)
+	applyBaseline(resp, baselinePath(req.Input, workspaceRoot), updateBaseline)
+
+	if outputFormat, _ := req.Input["output_format"].(string); outputFormat == "sarif" || outputFormat == "json" {
+		emitStructuredOutput(resp, outputFormat, req.Input)
+	}
 
 	return resp.Build(), nil
 }
 
+// emitStructuredOutput serializes resp's findings so far into a CI-friendly
+// format (output_format "sarif" or "json") and either writes it to the path
+// named by sarif_output/json_output, or attaches it as an info diagnostic
+// when no path is given so callers without workspace filesystem access can
+// still retrieve it.
+func emitStructuredOutput(resp *sdk.ResponseBuilder, format string, input map[string]any) {
+	findings := resp.Build().Findings
+
+	var data []byte
+	var err error
+	var destKey string
+	switch format {
+	case "sarif":
+		data, err = buildSARIF(findings)
+		destKey = "sarif_output"
+	case "json":
+		data, err = buildJSONReport(findings)
+		destKey = "json_output"
+	}
+	if err != nil {
+		resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+			fmt.Sprintf("failed to build %s output: %v", format, err), "risk-register")
+		return
+	}
+
+	if destPath, _ := input[destKey].(string); destPath != "" {
+		if err := os.WriteFile(destPath, data, 0o644); err != nil {
+			resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+				fmt.Sprintf("failed to write %s output to %s: %v", format, destPath, err), "risk-register")
+			return
+		}
+		resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("%s output written to %s", strings.ToUpper(format), destPath), "risk-register")
+		return
+	}
+
+	resp.Diagnostic(pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, string(data), format)
+}
+
 // scanFileForRisks scans a single source file for risk indicators.
-func scanFileForRisks(resp *sdk.ResponseBuilder, rc *riskContext, filePath, ext string) {
-	f, err := os.Open(filePath)
+// RISK-002 and RISK-005 are delegated to the Analyzer for ext, which uses
+// an AST backend where one exists (see analyzer.go). RISK-003 and RISK-004
+// for Go files are handled workspace-wide via rc.goIndex (see
+// emitCallSiteRisks); for every other language they fall back to a
+// per-file heuristic here, since no call graph is available for them.
+// Inline "// nox:risk-ignore RISK-XXX" suppressions are collected into
+// rc.suppressed before any finding is emitted, so RISK-001 through RISK-005
+// (including the workspace-wide RISK-003/004 Go path handled later) can honor
+// them. The file-level RISK-006 from enrichWithGit does not check them.
+func scanFileForRisks(resp *sdk.ResponseBuilder, rc *riskContext, filePath, ext string, cfg *scanConfig) {
+	src, err := os.ReadFile(filePath)
 	if err != nil {
 		return
 	}
-	defer func() { _ = f.Close() }()
 
-	scanner := bufio.NewScanner(f)
+	rc.suppressed[filePath] = parseSuppressions(src)
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
 	lineNum := 0
-	funcLineCount := 0
-	funcStartLine := 0
-	inFunc := false
-	maxNesting := 0
-	currentNesting := 0
+
+	var dbSites, externalSites []callSite
+	var hasPooling, hasFallback, hasRetry bool
 
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
 
 		// RISK-001: Security-related technical debt.
-		checkSecurityDebt(resp, filePath, lineNum, line)
+		if !isSuppressed(rc, filePath, lineNum, lineNum, "RISK-001") {
+			checkSecurityDebt(resp, filePath, lineNum, line)
+		}
 
-		// RISK-002: Deprecated API usage.
-		checkDeprecatedAPI(resp, filePath, lineNum, line, ext)
+		if ext == ".go" {
+			// Go call sites are collected workspace-wide by buildGoCallIndex
+			// so they can be checked against the call graph instead of a
+			// per-file boolean.
+			continue
+		}
 
-		// Track DB connections and external calls for RISK-003 and RISK-004.
-		if reSingleDBConn.MatchString(line) && !rc.hasDBConnection {
-			rc.hasDBConnection = true
-			rc.dbFile = filePath
-			rc.dbLine = lineNum
+		if reSingleDBConn.MatchString(line) {
+			dbSites = append(dbSites, callSite{File: filePath, Line: lineNum, Target: strings.TrimSpace(line)})
 		}
 		if reNoPooling.MatchString(line) {
-			rc.hasPooling = true
+			hasPooling = true
 		}
 		if reNoFallback.MatchString(line) {
-			rc.hasFallback = true
+			hasFallback = true
 		}
-		if reExternalCall.MatchString(line) && !rc.hasExternalCalls {
-			rc.hasExternalCalls = true
-			rc.externalCallFile = filePath
-			rc.externalCallLine = lineNum
+		if reExternalCall.MatchString(line) {
+			externalSites = append(externalSites, callSite{File: filePath, Line: lineNum, Target: strings.TrimSpace(line)})
 		}
 		if reRetryMechanism.MatchString(line) {
-			rc.hasRetryMech = true
-		}
-
-		// RISK-005: Code complexity tracking.
-		if reFuncStart.MatchString(line) {
-			if inFunc && funcLineCount > 50 {
-				resp.Finding(
-					"RISK-005",
-					sdk.SeverityLow,
-					sdk.ConfidenceHigh,
-					fmt.Sprintf("Long function detected (%d lines): increases maintenance risk", funcLineCount),
-				).
-					At(filePath, funcStartLine, funcStartLine+funcLineCount).
-					WithMetadata("risk_type", "complexity").
-					WithMetadata("line_count", fmt.Sprintf("%d", funcLineCount)).
-					Done()
-			}
-			inFunc = true
-			funcStartLine = lineNum
-			funcLineCount = 0
-			maxNesting = 0
-			currentNesting = 0
+			hasRetry = true
 		}
+	}
 
-		if inFunc {
-			funcLineCount++
-			trimmed := strings.TrimSpace(line)
-
-			// Track nesting depth.
-			if reNestedConditional.MatchString(line) {
-				// Count leading indentation as proxy for nesting depth.
-				indent := len(line) - len(strings.TrimLeft(line, " \t"))
-				// Normalize tabs to 4 spaces.
-				tabCount := strings.Count(line[:indent], "\t")
-				spaceCount := indent - tabCount
-				normalizedIndent := tabCount*4 + spaceCount
-				depth := normalizedIndent / 4
-
-				if depth > currentNesting {
-					currentNesting = depth
-				}
-				if currentNesting > maxNesting {
-					maxNesting = currentNesting
-				}
-			}
+	if ext != ".go" {
+		hasRetry = hasRetry || cfg.catalog.hasRetrySubstring(catalogLanguage(ext), src)
+		emitUnguardedCallSites(resp, rc, dbSites, "RISK-003", sdk.SeverityHigh, sdk.ConfidenceMedium,
+			"Single point of failure: database connection without pooling or fallback mechanism in this file",
+			"single_point_of_failure", !hasPooling && !hasFallback)
+		emitUnguardedCallSites(resp, rc, externalSites, "RISK-004", sdk.SeverityMedium, sdk.ConfidenceMedium,
+			"External service call without retry or circuit breaker mechanism in this file",
+			"missing_recovery", !hasRetry)
+	}
 
-			// Check for deep nesting.
-			if maxNesting >= 4 && (trimmed == "}" || trimmed == "end" || trimmed == "") {
-				// Emit once when we detect deep nesting in a function.
-				if maxNesting >= 4 {
-					resp.Finding(
-						"RISK-005",
-						sdk.SeverityLow,
-						sdk.ConfidenceHigh,
-						fmt.Sprintf("Deeply nested conditional logic (depth %d): increases cognitive complexity", maxNesting),
-					).
-						At(filePath, funcStartLine, lineNum).
-						WithMetadata("risk_type", "nesting_depth").
-						WithMetadata("max_depth", fmt.Sprintf("%d", maxNesting)).
-						Done()
-					maxNesting = 0 // Reset to avoid duplicate findings.
-				}
-			}
+	for _, f := range analyzerFor(ext, cfg.thresholds, cfg.catalog, cfg.pinnedVersions).Analyze(filePath, src) {
+		if isSuppressed(rc, filePath, f.StartLine, f.EndLine, f.RiskID) {
+			continue
 		}
+		f.emit(resp, filePath)
 	}
+}
 
-	// Check the last function in the file.
-	if inFunc && funcLineCount > 50 {
-		resp.Finding(
-			"RISK-005",
-			sdk.SeverityLow,
-			sdk.ConfidenceHigh,
-			fmt.Sprintf("Long function detected (%d lines): increases maintenance risk", funcLineCount),
-		).
-			At(filePath, funcStartLine, funcStartLine+funcLineCount).
-			WithMetadata("risk_type", "complexity").
-			WithMetadata("line_count", fmt.Sprintf("%d", funcLineCount)).
+// emitUnguardedCallSites emits one finding per site in sites when unguarded
+// is true, skipping sites suppressed by an inline nox:risk-ignore marker.
+// It's the non-Go fallback, where "guarded" is a per-file boolean rather
+// than a call-graph lookup.
+func emitUnguardedCallSites(resp *sdk.ResponseBuilder, rc *riskContext, sites []callSite, riskID string, severity pluginv1.Severity, confidence pluginv1.Confidence, message, riskType string, unguarded bool) {
+	if !unguarded {
+		return
+	}
+	for _, site := range sites {
+		if isSuppressed(rc, site.File, site.Line, site.Line, riskID) {
+			continue
+		}
+		resp.Finding(riskID, severity, confidence, message).
+			At(site.File, site.Line, site.Line).
+			WithMetadata("risk_type", riskType).
+			WithMetadata("call_target", site.Target).
+			WithFingerprint(fingerprint(riskID, site.File, site.Target, "")).
 			Done()
 	}
 }
@@ -273,73 +323,58 @@ func checkSecurityDebt(resp *sdk.ResponseBuilder, filePath string, lineNum int,
 		).
 			At(filePath, lineNum, lineNum).
 			WithMetadata("risk_type", "tech_debt").
+			WithFingerprint(fingerprint("RISK-001", filePath, line, "")).
 			Done()
 	}
 }
 
-// checkDeprecatedAPI checks for RISK-002: deprecated API usage.
-func checkDeprecatedAPI(resp *sdk.ResponseBuilder, filePath string, lineNum int, line, ext string) {
-	var matched bool
-	var detail string
-
-	switch ext {
-	case ".go":
-		if reDeprecatedGo.MatchString(line) {
-			matched = true
-			detail = "Go deprecated API"
-		}
-	case ".py":
-		if reDeprecatedPython.MatchString(line) {
-			matched = true
-			detail = "Python deprecated pattern"
+// emitCallSiteRisks emits one RISK-003 finding per Go DB-open call site, and
+// one RISK-004 finding per Go external-call site, whose enclosing function
+// (or a caller within maxCallerHops) has no pooling/fallback or retry
+// construct according to rc.goIndex. Sites suppressed by an inline
+// nox:risk-ignore marker (collected into rc.suppressed by scanFileForRisks
+// during the workspace walk) are skipped.
+func emitCallSiteRisks(resp *sdk.ResponseBuilder, rc *riskContext) {
+	for _, site := range rc.dbSites {
+		if rc.goIndex.hasGuardWithinHops(site.EnclosingFunc, maxCallerHops, guardDB) {
+			continue
 		}
-	case ".js", ".ts", ".jsx", ".tsx":
-		if reDeprecatedJS.MatchString(line) {
-			matched = true
-			detail = "JavaScript deprecated API"
+		if isSuppressed(rc, site.File, site.Line, site.Line, "RISK-003") {
+			continue
 		}
-	}
-
-	if matched {
-		resp.Finding(
-			"RISK-002",
-			sdk.SeverityMedium,
-			sdk.ConfidenceHigh,
-			fmt.Sprintf("Deprecated API usage detected (%s): %s", detail, strings.TrimSpace(line)),
-		).
-			At(filePath, lineNum, lineNum).
-			WithMetadata("risk_type", "deprecated_api").
-			WithMetadata("language", ext).
-			Done()
-	}
-}
-
-// emitWorkspaceRisks emits workspace-level risk findings for RISK-003 and RISK-004.
-func emitWorkspaceRisks(resp *sdk.ResponseBuilder, rc *riskContext) {
-	// RISK-003: Single point of failure.
-	if rc.hasDBConnection && !rc.hasPooling && !rc.hasFallback {
 		resp.Finding(
 			"RISK-003",
 			sdk.SeverityHigh,
 			sdk.ConfidenceHigh,
-			"Single point of failure: database connection without pooling or fallback mechanism",
+			fmt.Sprintf("Single point of failure: %s in %s has no pooling or fallback mechanism within %d caller hop(s)", site.Target, site.EnclosingFunc, maxCallerHops),
 		).
-			At(rc.dbFile, rc.dbLine, rc.dbLine).
+			At(site.File, site.Line, site.Line).
 			WithMetadata("risk_type", "single_point_of_failure").
 			WithMetadata("resource", "database").
+			WithMetadata("call_target", site.Target).
+			WithMetadata("enclosing_func", site.EnclosingFunc).
+			WithFingerprint(fingerprint("RISK-003", site.File, site.Target, site.EnclosingFunc)).
 			Done()
 	}
 
-	// RISK-004: Missing error recovery.
-	if rc.hasExternalCalls && !rc.hasRetryMech {
+	for _, site := range rc.externalSites {
+		if rc.goIndex.hasGuardWithinHops(site.EnclosingFunc, maxCallerHops, guardExternal) {
+			continue
+		}
+		if isSuppressed(rc, site.File, site.Line, site.Line, "RISK-004") {
+			continue
+		}
 		resp.Finding(
 			"RISK-004",
 			sdk.SeverityMedium,
-			sdk.ConfidenceMedium,
-			"External service calls detected without retry or circuit breaker mechanism",
+			sdk.ConfidenceHigh,
+			fmt.Sprintf("External call %s in %s has no retry or circuit breaker within %d caller hop(s)", site.Target, site.EnclosingFunc, maxCallerHops),
 		).
-			At(rc.externalCallFile, rc.externalCallLine, rc.externalCallLine).
+			At(site.File, site.Line, site.Line).
 			WithMetadata("risk_type", "missing_recovery").
+			WithMetadata("call_target", site.Target).
+			WithMetadata("enclosing_func", site.EnclosingFunc).
+			WithFingerprint(fingerprint("RISK-004", site.File, site.Target, site.EnclosingFunc)).
 			Done()
 	}
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/nox-hq/nox/sdk"
+)
+
+// regexAnalyzer is the line-based, regex-driven fallback used for any
+// language without an AST backend (and for Go files that fail to parse).
+// It reproduces the plugin's original RISK-005 heuristics; RISK-002 is
+// driven by catalog against catalogLanguage(ext).
+type regexAnalyzer struct {
+	ext        string
+	thresholds Thresholds
+	catalog    *Catalog
+	pinned     map[string]string
+}
+
+func (a *regexAnalyzer) Analyze(path string, src []byte) []Finding {
+	var findings []Finding
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	lineNum := 0
+	funcLineCount := 0
+	funcStartLine := 0
+	inFunc := false
+	maxNesting := 0
+	currentNesting := 0
+
+	flushLongFunc := func() {
+		if inFunc && funcLineCount > a.thresholds.MaxFuncLines {
+			findings = append(findings, Finding{
+				RiskID:     "RISK-005",
+				Severity:   sdk.SeverityLow,
+				Confidence: sdk.ConfidenceHigh,
+				Message:    fmt.Sprintf("Long function detected (%d lines): increases maintenance risk", funcLineCount),
+				StartLine:  funcStartLine,
+				EndLine:    funcStartLine + funcLineCount,
+				Metadata: map[string]string{
+					"risk_type":  "complexity",
+					"line_count": fmt.Sprintf("%d", funcLineCount),
+				},
+				Fingerprint: fingerprint("RISK-005", path, sourceLine(src, funcStartLine)+"|complexity", ""),
+			})
+		}
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		if entry, d, ok := deprecatedAPI(line, a.ext, a.catalog, a.pinned); ok {
+			findings = append(findings, Finding{
+				RiskID:     "RISK-002",
+				Severity:   entry.findingSeverity(),
+				Confidence: sdk.ConfidenceHigh,
+				Message:    fmt.Sprintf("Deprecated API usage detected (%s): %s — replacement: %s", d, strings.TrimSpace(line), entry.Replacement),
+				StartLine:  lineNum,
+				EndLine:    lineNum,
+				Metadata: map[string]string{
+					"risk_type": "deprecated_api",
+					"language":  a.ext,
+				},
+				Fingerprint: fingerprint("RISK-002", path, line, ""),
+			})
+		}
+
+		if reFuncStart.MatchString(line) {
+			flushLongFunc()
+			inFunc = true
+			funcStartLine = lineNum
+			funcLineCount = 0
+			maxNesting = 0
+			currentNesting = 0
+		}
+
+		if inFunc {
+			funcLineCount++
+			trimmed := strings.TrimSpace(line)
+
+			if reNestedConditional.MatchString(line) {
+				indent := len(line) - len(strings.TrimLeft(line, " \t"))
+				tabCount := strings.Count(line[:indent], "\t")
+				spaceCount := indent - tabCount
+				normalizedIndent := tabCount*4 + spaceCount
+				depth := normalizedIndent / 4
+
+				if depth > currentNesting {
+					currentNesting = depth
+				}
+				if currentNesting > maxNesting {
+					maxNesting = currentNesting
+				}
+			}
+
+			if maxNesting >= a.thresholds.MaxNesting && (trimmed == "}" || trimmed == "end" || trimmed == "") {
+				findings = append(findings, Finding{
+					RiskID:     "RISK-005",
+					Severity:   sdk.SeverityLow,
+					Confidence: sdk.ConfidenceHigh,
+					Message:    fmt.Sprintf("Deeply nested conditional logic (depth %d): increases cognitive complexity", maxNesting),
+					StartLine:  funcStartLine,
+					EndLine:    lineNum,
+					Metadata: map[string]string{
+						"risk_type": "nesting_depth",
+						"max_depth": fmt.Sprintf("%d", maxNesting),
+					},
+					Fingerprint: fingerprint("RISK-005", path, sourceLine(src, funcStartLine)+"|nesting_depth", ""),
+				})
+				maxNesting = 0 // Reset to avoid duplicate findings.
+			}
+		}
+	}
+
+	flushLongFunc()
+
+	return findings
+}
+
+// deprecatedAPI matches line against catalog's pattern-based entries for
+// catalogLanguage(ext) and returns a human-readable description of what
+// matched alongside the entry, gated by pinned versions where applicable.
+func deprecatedAPI(line, ext string, catalog *Catalog, pinned map[string]string) (CatalogEntry, string, bool) {
+	language := catalogLanguage(ext)
+	if language == "" {
+		return CatalogEntry{}, "", false
+	}
+	entry, ok := catalog.matchPattern(language, line, pinned)
+	if !ok {
+		return CatalogEntry{}, "", false
+	}
+	return entry, describeLanguage(language), true
+}
+
+// catalogLanguage maps a file extension to the "language" key used in the
+// deprecation catalog.
+func catalogLanguage(ext string) string {
+	switch ext {
+	case ".go":
+		return "go"
+	case ".py":
+		return "python"
+	case ".js", ".ts", ".jsx", ".tsx":
+		return "js"
+	case ".java":
+		return "java"
+	case ".cs":
+		return "dotnet"
+	default:
+		return ""
+	}
+}
+
+// describeLanguage returns the human-readable name used in RISK-002
+// messages for a catalog language key.
+func describeLanguage(language string) string {
+	switch language {
+	case "go":
+		return "Go deprecated API"
+	case "python":
+		return "Python deprecated pattern"
+	case "js":
+		return "JavaScript deprecated API"
+	default:
+		return language
+	}
+}
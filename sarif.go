@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// sarifSchemaURI and sarifVersion identify the SARIF document format emitted
+// by buildSARIF, per https://docs.oasis-open.org/sarif/sarif/v2.1.0.
+const (
+	sarifSchemaURI = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+	sarifVersion   = "2.1.0"
+)
+
+// ruleDescriptions gives a short, stable name and description for each
+// risk-register rule ID, used to populate SARIF's rule metadata.
+var ruleDescriptions = map[string]struct {
+	Name  string
+	Short string
+}{
+	"RISK-001": {"SecurityTechDebt", "Security-related technical debt marker (TODO/FIXME/HACK/XXX)"},
+	"RISK-002": {"DeprecatedAPI", "Deprecated or unsafe API usage"},
+	"RISK-003": {"SinglePointOfFailure", "Database connection without pooling or fallback mechanism"},
+	"RISK-004": {"MissingRecovery", "External service call without retry or circuit breaker"},
+	"RISK-005": {"CodeComplexity", "Excessive function length, cyclomatic/cognitive complexity, or nesting depth"},
+	"RISK-006": {"HotspotSecurityDebt", "High-churn file with security-related technical debt"},
+}
+
+// sarifLog is the top-level SARIF 2.1.0 document.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name,omitempty"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int32 `json:"startLine,omitempty"`
+	EndLine   int32 `json:"endLine,omitempty"`
+}
+
+// buildSARIF serializes findings into a SARIF 2.1.0 log so CI systems
+// (GitHub code scanning, GitLab) can ingest risk-register output alongside
+// other SAST tools. Rule metadata is deduplicated across the single run.
+func buildSARIF(findings []*pluginv1.Finding) ([]byte, error) {
+	seenRules := map[string]bool{}
+	var rules []sarifRule
+	results := make([]sarifResult, 0, len(findings))
+
+	for _, f := range findings {
+		if !seenRules[f.RuleId] {
+			seenRules[f.RuleId] = true
+			desc := ruleDescriptions[f.RuleId]
+			rules = append(rules, sarifRule{
+				ID:               f.RuleId,
+				Name:             desc.Name,
+				ShortDescription: sarifMessage{Text: desc.Short},
+			})
+		}
+
+		result := sarifResult{
+			RuleID:     f.RuleId,
+			Level:      severityToSARIFLevel(f.Severity),
+			Message:    sarifMessage{Text: f.Message},
+			Properties: f.Metadata,
+		}
+		if loc := f.Location; loc != nil {
+			result.Locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: loc.FilePath},
+					Region:           sarifRegion{StartLine: loc.StartLine, EndLine: loc.EndLine},
+				},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchemaURI,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "nox-risk-register",
+				Version:        version,
+				InformationURI: "https://github.com/Nox-HQ/nox-plugin-risk-register",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+// severityToSARIFLevel maps a risk-register severity to the closest SARIF
+// result level, since SARIF has no direct "critical" or "info" level.
+func severityToSARIFLevel(sev pluginv1.Severity) string {
+	switch sev {
+	case pluginv1.Severity_SEVERITY_CRITICAL, pluginv1.Severity_SEVERITY_HIGH:
+		return "error"
+	case pluginv1.Severity_SEVERITY_MEDIUM:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// jsonFinding is a flattened, proto-independent view of a finding for
+// output_format: "json", so tooling without protobuf bindings can still
+// consume risk-register results.
+type jsonFinding struct {
+	RuleID     string            `json:"rule_id"`
+	Severity   string            `json:"severity"`
+	Confidence string            `json:"confidence"`
+	Message    string            `json:"message"`
+	FilePath   string            `json:"file_path,omitempty"`
+	StartLine  int32             `json:"start_line,omitempty"`
+	EndLine    int32             `json:"end_line,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// buildJSONReport serializes findings into a plain JSON array, independent
+// of the plugin protocol's own encoding.
+func buildJSONReport(findings []*pluginv1.Finding) ([]byte, error) {
+	out := make([]jsonFinding, 0, len(findings))
+	for _, f := range findings {
+		jf := jsonFinding{
+			RuleID:     f.RuleId,
+			Severity:   f.Severity.String(),
+			Confidence: f.Confidence.String(),
+			Message:    f.Message,
+			Metadata:   f.Metadata,
+		}
+		if loc := f.Location; loc != nil {
+			jf.FilePath = loc.FilePath
+			jf.StartLine = loc.StartLine
+			jf.EndLine = loc.EndLine
+		}
+		out = append(out, jf)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}